@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Manpreet-Bhatti/Fulcrum/config"
+	"github.com/Manpreet-Bhatti/Fulcrum/pool"
+)
+
+// reloader owns Fulcrum's hot-reload and admin-API state: the on-disk
+// config path, the bearer token guarding admin endpoints, and enough
+// context (serverPool, maxCooldown, metrics buckets) to turn a
+// config.BackendConfig into a fully wired *pool.Backend the same way
+// main() does at startup.
+type reloader struct {
+	configPath  string
+	token       string
+	serverPool  *pool.ServerPool
+	maxCooldown time.Duration
+	metricsCfg  config.MetricsConfig
+
+	mux     sync.Mutex // serializes Reload/AddBackend/DrainAndRemove
+	current map[string]config.BackendConfig
+}
+
+// newReloader builds a reloader seeded with cfg's backend set, so the
+// first Reload call only has to apply whatever changed since cfg was
+// loaded.
+func newReloader(configPath string, cfg *config.Config, serverPool *pool.ServerPool, maxCooldown time.Duration) *reloader {
+	current := make(map[string]config.BackendConfig, len(cfg.Backends))
+
+	for _, bc := range cfg.Backends {
+		current[bc.Name] = bc
+	}
+
+	return &reloader{
+		configPath:  configPath,
+		token:       cfg.Admin.Token,
+		serverPool:  serverPool,
+		maxCooldown: maxCooldown,
+		metricsCfg:  cfg.Metrics,
+		current:     current,
+	}
+}
+
+// Reload re-reads configPath and applies the backend-set delta to
+// serverPool atomically: backends missing from the new config are
+// drained and removed, backends not seen before are added (and
+// health-checked before being eligible for selection), and backends
+// whose weight or health settings changed are updated in place.
+func (rl *reloader) Reload() error {
+	cfg, err := config.LoadConfig(rl.configPath)
+
+	if err != nil {
+		return fmt.Errorf("admin: reload config: %w", err)
+	}
+
+	wanted := make(map[string]config.BackendConfig, len(cfg.Backends))
+
+	for _, bc := range cfg.Backends {
+		wanted[bc.Name] = bc
+	}
+
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+
+	for name := range rl.current {
+		if _, ok := wanted[name]; !ok {
+			rl.drainAndRemoveLocked(name)
+			delete(rl.current, name)
+		}
+	}
+
+	for name, bc := range wanted {
+		if _, ok := rl.current[name]; ok {
+			rl.updateBackendLocked(bc)
+		} else if err := rl.addBackendLocked(bc); err != nil {
+			log.Printf("[Fulcrum] Admin: failed to add backend %q on reload: %v", name, err)
+
+			continue
+		}
+
+		rl.current[name] = bc
+	}
+
+	log.Printf("[Fulcrum] Config reloaded from %s", rl.configPath)
+
+	return nil
+}
+
+// AddBackend adds a single backend outside of a full reload, e.g. via
+// POST /admin/backends.
+func (rl *reloader) AddBackend(bc config.BackendConfig) error {
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+
+	if bc.Name == "" {
+		return fmt.Errorf("admin: backend name is required")
+	}
+
+	if _, exists := rl.current[bc.Name]; exists {
+		return fmt.Errorf("admin: backend %q already exists", bc.Name)
+	}
+
+	if err := rl.addBackendLocked(bc); err != nil {
+		return err
+	}
+
+	rl.current[bc.Name] = bc
+
+	return nil
+}
+
+// Drain marks name as draining without removing it, so it stops
+// receiving new requests but its in-flight connections finish normally.
+// Used to take a backend out of rotation ahead of a blue/green cutover.
+func (rl *reloader) Drain(name string) bool {
+	backend := rl.serverPool.GetBackendByName(name)
+
+	if backend == nil {
+		return false
+	}
+
+	backend.SetDraining(true)
+
+	return true
+}
+
+// DrainAndRemove marks name as draining, then once its ActiveConnections
+// reach zero, removes it from the pool.
+func (rl *reloader) DrainAndRemove(name string) bool {
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+
+	if rl.drainAndRemoveLocked(name) {
+		delete(rl.current, name)
+
+		return true
+	}
+
+	return false
+}
+
+func (rl *reloader) drainAndRemoveLocked(name string) bool {
+	backend := rl.serverPool.GetBackendByName(name)
+
+	if backend == nil {
+		return false
+	}
+
+	backend.SetDraining(true)
+
+	go func() {
+		for atomic.LoadInt64(&backend.ActiveConnections) > 0 {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		rl.serverPool.RemoveBackend(name)
+
+		log.Printf("[Fulcrum] Admin: drained and removed backend %s", name)
+	}()
+
+	return true
+}
+
+func (rl *reloader) addBackendLocked(bc config.BackendConfig) error {
+	serverURL, err := url.Parse(bc.URL)
+
+	if err != nil {
+		return fmt.Errorf("admin: invalid backend URL %q: %w", bc.URL, err)
+	}
+
+	weight := bc.Weight
+	if weight == 0 {
+		weight = 1
+	}
+
+	backend := &pool.Backend{
+		Name:          bc.Name,
+		URL:           serverURL,
+		Alive:         false, // not eligible until the first probe succeeds
+		Weight:        weight,
+		Probe:         buildHealthProbe(bc.Health),
+		ProbeInterval: time.Duration(bc.Health.Interval) * time.Second,
+		MaxCooldown:   rl.maxCooldown,
+		Latency:       pool.NewHistogram(rl.metricsCfg.Buckets),
+	}
+
+	backend.Transport = buildTransport(bc, serverURL, rl.serverPool, rl.maxCooldown)
+
+	rl.serverPool.AddBackend(backend)
+	pool.ProbeOnce(backend)
+	rl.serverPool.StartBackendHealthCheck(backend)
+
+	log.Printf("[Fulcrum] Admin: added backend %s (%s)", bc.Name, bc.URL)
+
+	return nil
+}
+
+func (rl *reloader) updateBackendLocked(bc config.BackendConfig) {
+	backend := rl.serverPool.GetBackendByName(bc.Name)
+
+	if backend == nil {
+		return
+	}
+
+	weight := bc.Weight
+	if weight == 0 {
+		weight = 1
+	}
+
+	backend.Weight = weight
+
+	backend.Mux.Lock()
+	backend.Probe = buildHealthProbe(bc.Health)
+	backend.ProbeInterval = time.Duration(bc.Health.Interval) * time.Second
+	backend.Mux.Unlock()
+}
+
+// requireToken wraps next so it only runs for requests bearing the
+// configured admin bearer token. An empty token disables the admin API
+// entirely rather than accepting unauthenticated requests.
+func (rl *reloader) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rl.token == "" {
+			http.Error(w, "[Fulcrum] Admin API disabled: no token configured", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+rl.token {
+			http.Error(w, "[Fulcrum] Unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// registerAdminRoutes mounts the admin API on mux: POST /admin/reload,
+// GET/POST /admin/backends, DELETE /admin/backends/{name}, and
+// POST /admin/backends/{name}/drain.
+func (rl *reloader) registerAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/reload", rl.requireToken(rl.handleReload))
+	mux.HandleFunc("/admin/backends", rl.requireToken(rl.handleBackends))
+	mux.HandleFunc("/admin/backends/", rl.requireToken(rl.handleBackendByName))
+}
+
+func (rl *reloader) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if err := rl.Reload(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rl *reloader) handleBackends(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rl.serverPool.AllBackends())
+	case http.MethodPost:
+		var bc config.BackendConfig
+
+		if err := json.NewDecoder(r.Body).Decode(&bc); err != nil {
+			http.Error(w, "invalid backend config", http.StatusBadRequest)
+
+			return
+		}
+
+		if err := rl.AddBackend(bc); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (rl *reloader) handleBackendByName(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/admin/backends/")
+
+	if draining := strings.TrimSuffix(name, "/drain"); draining != name {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if !rl.Drain(draining) {
+			http.Error(w, "backend not found", http.StatusNotFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if !rl.DrainAndRemove(name) {
+		http.Error(w, "backend not found", http.StatusNotFound)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
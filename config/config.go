@@ -6,14 +6,86 @@ import (
 )
 
 type BackendConfig struct {
-	URL    string `json:"url"`
-	Name   string `json:"name"`
-	Weight int    `json:"weight"`
+	URL    string       `json:"url"`
+	Name   string       `json:"name"`
+	Weight int          `json:"weight"`
+	Health HealthConfig `json:"health"`
+
+	// Type selects the transport Fulcrum speaks to this backend: "http"
+	// (default) for a standard reverse proxy, or "fastcgi" to dial the
+	// backend as a FastCGI application (e.g. PHP-FPM). Root, Index,
+	// SplitPath, and Env are only used when Type is "fastcgi".
+	Type      string            `json:"type,omitempty"`
+	Root      string            `json:"root,omitempty"`
+	Index     string            `json:"index,omitempty"`
+	SplitPath string            `json:"split_path,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// HealthConfig describes the active probe Fulcrum runs against a backend.
+// Type is one of: "http" (GET Path, check ExpectStatus), "body_match"
+// (GET Path, check ExpectStatus and match ExpectBody as a regex against
+// the response body), or "tcp" (raw dial, no HTTP involved). Interval
+// and Timeout are in seconds; ExpectStatus is a range like "200-399".
+type HealthConfig struct {
+	Type         string `json:"type"`
+	Path         string `json:"path,omitempty"`
+	Interval     int    `json:"interval,omitempty"`
+	Timeout      int    `json:"timeout,omitempty"`
+	ExpectStatus string `json:"expect_status,omitempty"`
+	ExpectBody   string `json:"expect_body,omitempty"`
+}
+
+// PolicyConfig selects and configures the SelectionPolicy a listener uses
+// to pick a backend per request. Type is one of: "round_robin",
+// "weighted_round_robin", "random", "least_conn", "ip_hash", "uri_hash",
+// "header_hash", "cookie", "first", or "fallback". When Type is
+// "fallback", Fallback lists the policy types to try in order (e.g.
+// ["cookie", "least_conn"]).
+type PolicyConfig struct {
+	Type       string   `json:"type"`
+	HeaderName string   `json:"header_name,omitempty"`
+	CookieName string   `json:"cookie_name,omitempty"`
+	Fallback   []string `json:"fallback,omitempty"`
+}
+
+// RetryConfig bounds how much of a request body Fulcrum will buffer so it
+// can be replayed against the next backend on retry. A zero MaxMem/HardCap
+// (the whole struct left unset) falls back to
+// middleware.DefaultRetryMaxMemMB/DefaultRetryHardCapMB rather than
+// spooling unboundedly to disk.
+type RetryConfig struct {
+	MaxMem  int64 `json:"max_mem_mb"`  // megabytes spooled in memory before spilling to a temp file
+	HardCap int64 `json:"hard_cap_mb"` // megabytes past which the spool stops buffering and the request is not retried
+}
+
+// CircuitConfig bounds the exponential backoff used while a backend's
+// circuit breaker is open. MaxCooldown is in seconds.
+type CircuitConfig struct {
+	MaxCooldown int `json:"max_cooldown"`
+}
+
+// MetricsConfig configures the /metrics endpoint's per-backend latency
+// histogram. Buckets are request-duration boundaries in seconds; an
+// empty slice falls back to pool.DefaultHistogramBuckets.
+type MetricsConfig struct {
+	Buckets []float64 `json:"buckets,omitempty"`
+}
+
+// AdminConfig guards the hot-reload and backend-management admin API
+// behind a bearer token. An empty Token disables the admin API entirely.
+type AdminConfig struct {
+	Token string `json:"token,omitempty"`
 }
 
 type Config struct {
 	LBPort   int             `json:"lb_port"`
 	Backends []BackendConfig `json:"backends"`
+	Policy   PolicyConfig    `json:"policy"`
+	Retry    RetryConfig     `json:"retry"`
+	Circuit  CircuitConfig   `json:"circuit"`
+	Metrics  MetricsConfig   `json:"metrics"`
+	Admin    AdminConfig     `json:"admin"`
 }
 
 func LoadConfig(file string) (*Config, error) {
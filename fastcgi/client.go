@@ -0,0 +1,164 @@
+package fastcgi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestID is always 1: Fulcrum opens one connection per request and
+// never multiplexes several FCGI requests onto it.
+const requestID uint16 = 1
+
+// Do opens a connection to a FastCGI application at network/address
+// ("tcp" or "unix"), sends a single FCGI_RESPONDER request built from
+// params and stdin, and parses the CGI-style output it streams back on
+// FCGI_STDOUT into an *http.Response.
+func Do(network, address string, timeout time.Duration, params map[string]string, stdin io.Reader) (*http.Response, error) {
+	conn, err := net.DialTimeout(network, address, dialTimeout(timeout))
+
+	if err != nil {
+		return nil, fmt.Errorf("fastcgi: dial %s %s: %w", network, address, err)
+	}
+
+	defer conn.Close()
+
+	if timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	if err := beginRequest(conn); err != nil {
+		return nil, fmt.Errorf("fastcgi: begin request: %w", err)
+	}
+
+	if err := writeStream(conn, typeParams, requestID, encodeParams(params)); err != nil {
+		return nil, fmt.Errorf("fastcgi: write params: %w", err)
+	}
+
+	body, err := io.ReadAll(stdin)
+
+	if err != nil {
+		return nil, fmt.Errorf("fastcgi: read request body: %w", err)
+	}
+
+	if err := writeStream(conn, typeStdin, requestID, body); err != nil {
+		return nil, fmt.Errorf("fastcgi: write stdin: %w", err)
+	}
+
+	return readResponse(conn)
+}
+
+func dialTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return 5 * time.Second
+	}
+
+	return timeout
+}
+
+func beginRequest(w io.Writer) error {
+	// role (2 bytes), flags (1 byte, no keep-alive), 5 reserved bytes.
+	content := []byte{0, roleResponder, 0, 0, 0, 0, 0, 0}
+
+	return writeRecord(w, typeBeginRequest, requestID, content)
+}
+
+// readResponse reads FCGI records until FCGI_END_REQUEST, collecting
+// FCGI_STDOUT into the eventual response and surfacing anything written
+// to FCGI_STDERR as an error.
+func readResponse(r io.Reader) (*http.Response, error) {
+	var stdout, stderr bytes.Buffer
+
+	for {
+		h, err := readHeader(r)
+
+		if err != nil {
+			return nil, fmt.Errorf("fastcgi: read record header: %w", err)
+		}
+
+		content := make([]byte, h.ContentLength)
+
+		if _, err := io.ReadFull(r, content); err != nil {
+			return nil, fmt.Errorf("fastcgi: read record body: %w", err)
+		}
+
+		if h.PaddingLength > 0 {
+			if _, err := io.CopyN(io.Discard, r, int64(h.PaddingLength)); err != nil {
+				return nil, fmt.Errorf("fastcgi: discard padding: %w", err)
+			}
+		}
+
+		switch h.Type {
+		case typeStdout:
+			stdout.Write(content)
+		case typeStderr:
+			stderr.Write(content)
+		case typeEndRequest:
+			if stderr.Len() > 0 {
+				return nil, fmt.Errorf("fastcgi: application stderr: %s", stderr.String())
+			}
+
+			return parseCGIResponse(stdout.Bytes())
+		}
+	}
+}
+
+// parseCGIResponse splits CGI-style output (a header block, a blank
+// line, then the body) into an *http.Response, translating the CGI
+// "Status" header into the response status line.
+func parseCGIResponse(out []byte) (*http.Response, error) {
+	if len(out) == 0 {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(out)))
+
+	mimeHeader, err := reader.ReadMIMEHeader()
+
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("fastcgi: parse response headers: %w", err)
+	}
+
+	status := http.StatusOK
+
+	if s := mimeHeader.Get("Status"); s != "" {
+		if fields := strings.Fields(s); len(fields) > 0 {
+			if code, convErr := strconv.Atoi(fields[0]); convErr == nil {
+				status = code
+			}
+		}
+
+		mimeHeader.Del("Status")
+	}
+
+	body, err := io.ReadAll(reader.R)
+
+	if err != nil {
+		return nil, fmt.Errorf("fastcgi: read response body: %w", err)
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header(mimeHeader),
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}, nil
+}
@@ -0,0 +1,142 @@
+// Package fastcgi implements just enough of the FastCGI protocol
+// (https://fastcgi-archives.github.io/FastCGI_Specification.html) to
+// proxy a single HTTP request to an FCGI_RESPONDER application such as
+// PHP-FPM and translate its CGI-style output back into an *http.Response.
+package fastcgi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+const (
+	version1 = 1
+
+	typeBeginRequest = 1
+	typeEndRequest   = 3
+	typeParams       = 4
+	typeStdin        = 5
+	typeStdout       = 6
+	typeStderr       = 7
+
+	roleResponder = 1
+
+	maxRecordContentLength = 65535
+)
+
+type header struct {
+	Version       uint8
+	Type          uint8
+	RequestID     uint16
+	ContentLength uint16
+	PaddingLength uint8
+	Reserved      uint8
+}
+
+func (h *header) bytes() []byte {
+	b := make([]byte, 8)
+	b[0] = h.Version
+	b[1] = h.Type
+	binary.BigEndian.PutUint16(b[2:4], h.RequestID)
+	binary.BigEndian.PutUint16(b[4:6], h.ContentLength)
+	b[6] = h.PaddingLength
+	b[7] = h.Reserved
+
+	return b
+}
+
+func readHeader(r io.Reader) (header, error) {
+	buf := make([]byte, 8)
+
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return header{}, err
+	}
+
+	return header{
+		Version:       buf[0],
+		Type:          buf[1],
+		RequestID:     binary.BigEndian.Uint16(buf[2:4]),
+		ContentLength: binary.BigEndian.Uint16(buf[4:6]),
+		PaddingLength: buf[6],
+		Reserved:      buf[7],
+	}, nil
+}
+
+// writeRecord writes a single FCGI record of the given type.
+func writeRecord(w io.Writer, recType uint8, reqID uint16, content []byte) error {
+	h := header{Version: version1, Type: recType, RequestID: reqID, ContentLength: uint16(len(content))}
+
+	if _, err := w.Write(h.bytes()); err != nil {
+		return err
+	}
+
+	if len(content) == 0 {
+		return nil
+	}
+
+	_, err := w.Write(content)
+
+	return err
+}
+
+// writeStream writes data as a sequence of records of type recType,
+// each at most maxRecordContentLength bytes, followed by the
+// zero-length record that terminates an FCGI_PARAMS or FCGI_STDIN
+// stream.
+func writeStream(w io.Writer, recType uint8, reqID uint16, data []byte) error {
+	for len(data) > 0 {
+		n := len(data)
+
+		if n > maxRecordContentLength {
+			n = maxRecordContentLength
+		}
+
+		if err := writeRecord(w, recType, reqID, data[:n]); err != nil {
+			return err
+		}
+
+		data = data[n:]
+	}
+
+	return writeRecord(w, recType, reqID, nil)
+}
+
+// encodeParams encodes an FCGI_PARAMS name-value pair stream: each
+// name and value is prefixed by its length, 1 byte if it fits in 7
+// bits or 4 bytes (with the top bit set) otherwise.
+func encodeParams(params map[string]string) []byte {
+	keys := make([]string, 0, len(params))
+
+	for k := range params {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+
+	for _, k := range keys {
+		v := params[k]
+
+		writeParamLen(&buf, len(k))
+		writeParamLen(&buf, len(v))
+		buf.WriteString(k)
+		buf.WriteString(v)
+	}
+
+	return buf.Bytes()
+}
+
+func writeParamLen(buf *bytes.Buffer, n int) {
+	if n <= 127 {
+		buf.WriteByte(byte(n))
+
+		return
+	}
+
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(n)|0x80000000)
+	buf.Write(b)
+}
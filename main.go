@@ -7,7 +7,13 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/Manpreet-Bhatti/Fulcrum/config"
@@ -15,14 +21,19 @@ import (
 	"github.com/Manpreet-Bhatti/Fulcrum/pool"
 )
 
+// configPath is where main loads Config from at startup and where the
+// reloader re-reads it from on SIGHUP or POST /admin/reload.
+const configPath = "config.json"
+
 func main() {
-	cfg, err := config.LoadConfig("config.json")
+	cfg, err := config.LoadConfig(configPath)
 
 	if err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
 	serverPool := &pool.ServerPool{}
+	maxCooldown := time.Duration(cfg.Circuit.MaxCooldown) * time.Second
 
 	for _, u := range cfg.Backends {
 		serverURL, err := url.Parse(u.URL)
@@ -31,112 +42,386 @@ func main() {
 			log.Fatalf("Invalid backend URL: %v", err)
 		}
 
-		proxy := httputil.NewSingleHostReverseProxy(serverURL)
-		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, e error) {
-			log.Printf("[%s] %s", serverURL.Host, e.Error())
+		weight := u.Weight
+		if weight == 0 {
+			weight = 1
+		}
 
-			if b := serverPool.GetBackend(serverURL); b != nil {
-				atomic.AddUint64(&b.FailedRequests, 1)
-			}
+		probeInterval := time.Duration(u.Health.Interval) * time.Second
 
-			serverPool.MarkBackendStatus(serverURL, false)
+		backend := &pool.Backend{
+			Name:          u.Name,
+			URL:           serverURL,
+			Alive:         true,
+			Weight:        weight,
+			Probe:         buildHealthProbe(u.Health),
+			ProbeInterval: probeInterval,
+			MaxCooldown:   maxCooldown,
+			Latency:       pool.NewHistogram(cfg.Metrics.Buckets),
+		}
 
-			retries, _ := r.Context().Value(pool.RetryCtxKey).(int)
+		backend.Transport = buildTransport(u, serverURL, serverPool, maxCooldown)
 
-			if retries < pool.RetryAttempts {
-				time.Sleep(10 * time.Millisecond)
+		serverPool.AddBackend(backend)
 
-				retryPeer := serverPool.GetNextPeer()
+		log.Printf("Configured backend: %s (Weight: %d)\n", serverURL, weight)
+	}
 
-				if retryPeer != nil {
-					log.Printf("[Fulcrum] Retrying request on %s (Attempt %d)", retryPeer.URL, retries+1)
+	serverPool.Policy = buildSelectionPolicy(cfg.Policy)
 
-					ctx := context.WithValue(r.Context(), pool.RetryCtxKey, retries+1)
+	go serverPool.StartHealthCheck()
 
-					retryPeer.ReverseProxy.ServeHTTP(w, r.WithContext(ctx))
+	rl := newReloader(configPath, cfg, serverPool, maxCooldown)
+	go watchSIGHUP(rl)
 
-					return
-				}
+	go func() {
+		log.Println("📊 Dashboard started at :8081")
+
+		dashboardMux := http.NewServeMux()
+		dashboardMux.HandleFunc("/", serverPool.ServeDashboard)
+		dashboardMux.HandleFunc("/metrics", serverPool.ServeMetrics)
+		rl.registerAdminRoutes(dashboardMux)
+
+		http.ListenAndServe(":8081", dashboardMux)
+	}()
+
+	server := http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.LBPort),
+		Handler: middleware.LoggingMiddleware(middleware.RetryableBody(cfg.Retry)(newLoadBalancerHandler(serverPool))),
+	}
+
+	log.Printf("⚖️  Fulcrum Load Balancer starting on port %d\n", cfg.LBPort)
+
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// watchSIGHUP re-reads configPath through rl every time the process
+// receives SIGHUP, the conventional signal for "reload your config"
+// (as nginx and many other daemons use it).
+func watchSIGHUP(rl *reloader) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	for range sigCh {
+		log.Println("[Fulcrum] SIGHUP received, reloading config")
+
+		if err := rl.Reload(); err != nil {
+			log.Printf("[Fulcrum] Reload failed: %v", err)
+		}
+	}
+}
+
+// newLoadBalancerHandler returns the handler that picks a backend for
+// each incoming request and proxies to it. Upgrade requests (WebSocket
+// and friends) are pinned to the peer chosen for the handshake: no
+// retries happen once bytes may have started flowing, and the
+// ResponseWriter is wrapped so the error handler can detect a completed
+// hijack before attempting to write a fallback body. Non-upgrade requests
+// are timed and their status recorded against whichever backend actually
+// produced the final response for /metrics: the error handler updates
+// StatusWriter.Backend when it retries the request on a different peer.
+func newLoadBalancerHandler(serverPool *pool.ServerPool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), pool.RetryCtxKey, 0)
+		peer := serverPool.NextPeer(r)
+
+		if peer == nil {
+			http.Error(w, "Service not available", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		if cookieAware, ok := serverPool.Policy.(pool.CookieAware); ok {
+			cookieAware.SetStickyCookie(w, peer)
+		}
+
+		sw := &pool.StatusWriter{ResponseWriter: w}
+		var rw http.ResponseWriter = sw
+		upgrade := isUpgradeRequest(r)
+
+		if upgrade {
+			ctx = context.WithValue(ctx, pool.NoRetryCtxKey, true)
+			rw = &pool.HijackTrackingWriter{ResponseWriter: sw}
+
+			atomic.AddUint64(&peer.ActiveUpgrades, 1)
+			defer atomic.AddUint64(&peer.ActiveUpgrades, ^uint64(0)) // -1
+		}
+
+		atomic.AddInt64(&peer.ActiveConnections, 1)
+		atomic.AddUint64(&peer.TotalRequests, 1)
+		defer atomic.AddInt64(&peer.ActiveConnections, -1)
+
+		start := time.Now()
+		peer.Transport.ServeHTTP(rw, r.WithContext(ctx))
+
+		if !upgrade {
+			servedBy := peer
+			if sw.Backend != nil {
+				servedBy = sw.Backend
 			}
 
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte("[Fulcrum] All backends failed"))
+			servedBy.RecordRequestMetrics(sw.Status, time.Since(start))
 		}
+	})
+}
+
+// isUpgradeRequest reports whether r is asking to switch protocols, e.g.
+// a WebSocket handshake.
+func isUpgradeRequest(r *http.Request) bool {
+	if r.Header.Get("Upgrade") == "" {
+		return false
+	}
 
-		proxy.ModifyResponse = func(response *http.Response) error {
-			if response.StatusCode >= 500 {
-				backend := serverPool.GetBackend(serverURL)
+	for _, token := range strings.Split(r.Header.Get("Connection"), ",") {
+		if strings.EqualFold(strings.TrimSpace(token), "Upgrade") {
+			return true
+		}
+	}
 
-				if backend != nil {
-					failures := atomic.AddUint64(&backend.ConsecutiveFailures, 1)
+	return false
+}
 
-					if failures >= 3 {
-						log.Printf("🔥 CIRCUIT BREAKER TRIGGERED: %s marked down", serverURL)
+// newErrorHandler builds the ReverseProxy.ErrorHandler for the backend
+// at serverURL: it records the failure against the circuit breaker (the
+// same path a 5xx response or a failed active probe takes) and retries
+// the request on the next peer unless it's hijacked (bytes already
+// flowed, e.g. an upgraded connection) or pinned not to retry.
+func newErrorHandler(serverPool *pool.ServerPool, serverURL *url.URL) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, e error) {
+		log.Printf("[%s] %s", serverURL.Host, e.Error())
 
-						backend.SetAlive(false)
-					}
-				}
-			} else {
-				backend := serverPool.GetBackend(serverURL)
+		if hw, ok := w.(*pool.HijackTrackingWriter); ok && hw.Hijacked() {
+			return
+		}
+
+		if b := serverPool.GetBackend(serverURL); b != nil {
+			atomic.AddUint64(&b.FailedRequests, 1)
+			b.RecordCircuitFailure(b.MaxCooldown)
+		}
+
+		noRetry, _ := r.Context().Value(pool.NoRetryCtxKey).(bool)
+		retries, _ := r.Context().Value(pool.RetryCtxKey).(int)
+
+		if !noRetry && retries < pool.RetryAttempts && middleware.ResetRetryBody(r) {
+			time.Sleep(10 * time.Millisecond)
+
+			retryPeer := serverPool.NextPeer(r)
+
+			if retryPeer != nil {
+				log.Printf("[Fulcrum] Retrying request on %s (Attempt %d)", retryPeer.URL, retries+1)
 
-				if backend != nil {
-					atomic.StoreUint64(&backend.ConsecutiveFailures, 0)
+				serverPool.RecordRetry()
+
+				if sw, ok := w.(*pool.StatusWriter); ok {
+					sw.Backend = retryPeer
 				}
+
+				ctx := context.WithValue(r.Context(), pool.RetryCtxKey, retries+1)
+
+				retryPeer.Transport.ServeHTTP(w, r.WithContext(ctx))
+
+				return
 			}
+		} else if !noRetry && retries < pool.RetryAttempts {
+			log.Printf("[Fulcrum] Request body cannot be replayed (over retry hard cap or never spooled), giving up")
+		}
 
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("[Fulcrum] All backends failed"))
+	}
+}
+
+// newModifyResponse builds the ModifyResponse hook for the backend at
+// serverURL: it feeds every non-upgrade response into the consecutive
+// failure counter that drives the circuit breaker.
+func newModifyResponse(serverPool *pool.ServerPool, serverURL *url.URL, maxCooldown time.Duration) func(*http.Response) error {
+	return func(response *http.Response) error {
+		if response.StatusCode == http.StatusSwitchingProtocols {
 			return nil
 		}
 
-		weight := u.Weight
-		if weight == 0 {
-			weight = 1
-		}
+		backend := serverPool.GetBackend(serverURL)
 
-		backend := &pool.Backend{
-			Name:         u.Name,
-			URL:          serverURL,
-			ReverseProxy: proxy,
-			Alive:        true,
+		if backend == nil {
+			return nil
 		}
 
-		for i := 0; i < weight; i++ {
-			serverPool.AddBackend(backend)
+		if response.StatusCode >= 500 {
+			failures := atomic.AddUint64(&backend.ConsecutiveFailures, 1)
+
+			if failures >= 3 {
+				log.Printf("🔥 CIRCUIT BREAKER TRIGGERED: %s marked down", serverURL)
+
+				backend.RecordCircuitFailure(maxCooldown)
+			}
+		} else {
+			atomic.StoreUint64(&backend.ConsecutiveFailures, 0)
 		}
 
-		log.Printf("Configured backend: %s (Weight: %d)\n", serverURL, weight)
+		return nil
 	}
+}
 
-	go serverPool.StartHealthCheck()
+// buildTransport constructs the Transport that a backend's requests
+// should be served through: a FastCGI client when u.Type is "fastcgi",
+// otherwise a standard HTTP reverse proxy.
+func buildTransport(u config.BackendConfig, serverURL *url.URL, serverPool *pool.ServerPool, maxCooldown time.Duration) pool.Transport {
+	if u.Type == "fastcgi" {
+		return pool.NewFastCGITransport(buildFastCGIConfig(u, serverURL), newErrorHandler(serverPool, serverURL), newModifyResponse(serverPool, serverURL, maxCooldown))
+	}
 
-	go func() {
-		log.Println("📊 Dashboard started at :8081")
-		http.ListenAndServe(":8081", http.HandlerFunc(serverPool.ServeDashboard))
-	}()
+	proxy := httputil.NewSingleHostReverseProxy(serverURL)
+	proxy.ErrorHandler = newErrorHandler(serverPool, serverURL)
+	proxy.ModifyResponse = newModifyResponse(serverPool, serverURL, maxCooldown)
 
-	lbHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.WithValue(r.Context(), pool.RetryCtxKey, 0)
-		peer := serverPool.GetNextPeerLeastConnections()
+	return pool.NewHTTPTransport(proxy)
+}
 
-		if peer != nil {
-			atomic.AddInt64(&peer.ActiveConnections, 1)
-			atomic.AddUint64(&peer.TotalRequests, 1)
-			defer atomic.AddInt64(&peer.ActiveConnections, -1)
-			peer.ReverseProxy.ServeHTTP(w, r.WithContext(ctx))
+// buildFastCGIConfig translates a "fastcgi" backend's config and URL into
+// the dial network/address and CGI parameters a fastcgiTransport needs.
+// serverURL's scheme selects the network: "unix" dials serverURL.Path as
+// a socket, anything else dials serverURL.Host over TCP.
+func buildFastCGIConfig(u config.BackendConfig, serverURL *url.URL) pool.FastCGIConfig {
+	cfg := pool.FastCGIConfig{
+		Root:    u.Root,
+		Index:   u.Index,
+		Env:     u.Env,
+		Timeout: time.Duration(u.Health.Timeout) * time.Second,
+	}
 
-			return
+	if serverURL.Scheme == "unix" {
+		cfg.Network = "unix"
+		cfg.Address = serverURL.Path
+	} else {
+		cfg.Network = "tcp"
+		cfg.Address = serverURL.Host
+	}
+
+	if u.SplitPath != "" {
+		expr, err := regexp.Compile(u.SplitPath)
+
+		if err != nil {
+			log.Printf("[Fulcrum] Invalid split_path pattern %q: %v", u.SplitPath, err)
+		} else {
+			cfg.SplitPath = expr
 		}
+	}
 
-		http.Error(w, "Service not available", http.StatusServiceUnavailable)
-	})
+	return cfg
+}
 
-	server := http.Server{
-		Addr:    fmt.Sprintf(":%d", cfg.LBPort),
-		Handler: middleware.LoggingMiddleware(lbHandler),
+// buildHealthProbe constructs the HealthProbe described by cfg,
+// defaulting to a plain TCP dial when cfg.Type is empty or unrecognized.
+func buildHealthProbe(cfg config.HealthConfig) pool.HealthProbe {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	min, max := parseStatusRange(cfg.ExpectStatus)
+
+	switch cfg.Type {
+	case "http":
+		return &pool.HTTPProbe{
+			Path:            cfg.Path,
+			Timeout:         timeout,
+			ExpectStatusMin: min,
+			ExpectStatusMax: max,
+		}
+	case "body_match":
+		probe := &pool.BodyMatchProbe{
+			HTTPProbe: pool.HTTPProbe{
+				Path:            cfg.Path,
+				Timeout:         timeout,
+				ExpectStatusMin: min,
+				ExpectStatusMax: max,
+			},
+		}
+
+		if cfg.ExpectBody != "" {
+			expr, err := regexp.Compile(cfg.ExpectBody)
+
+			if err != nil {
+				log.Printf("[Fulcrum] Invalid expect_body pattern %q: %v", cfg.ExpectBody, err)
+			} else {
+				probe.Expect = expr
+			}
+		}
+
+		return probe
+	case "tcp", "":
+		return &pool.TCPProbe{Timeout: timeout}
+	default:
+		log.Printf("[Fulcrum] Unknown health check type %q, defaulting to tcp", cfg.Type)
+
+		return &pool.TCPProbe{Timeout: timeout}
 	}
+}
 
-	log.Printf("⚖️  Fulcrum Load Balancer starting on port %d\n", cfg.LBPort)
+// parseStatusRange parses an "expect_status" string like "200-399" or a
+// single code like "204" into inclusive bounds. An empty or malformed
+// string yields (0, 0), which HTTPProbe treats as its own 200-399
+// default.
+func parseStatusRange(expect string) (int, int) {
+	if expect == "" {
+		return 0, 0
+	}
 
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatal(err)
+	if lo, hi, ok := strings.Cut(expect, "-"); ok {
+		minCode, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		maxCode, err2 := strconv.Atoi(strings.TrimSpace(hi))
+
+		if err1 != nil || err2 != nil {
+			return 0, 0
+		}
+
+		return minCode, maxCode
+	}
+
+	code, err := strconv.Atoi(strings.TrimSpace(expect))
+
+	if err != nil {
+		return 0, 0
+	}
+
+	return code, code
+}
+
+// buildSelectionPolicy constructs the SelectionPolicy described by cfg,
+// defaulting to round robin when cfg.Type is empty or unrecognized.
+func buildSelectionPolicy(cfg config.PolicyConfig) pool.SelectionPolicy {
+	switch cfg.Type {
+	case "weighted_round_robin":
+		return &pool.WeightedRoundRobinPolicy{}
+	case "random":
+		return pool.RandomPolicy{}
+	case "least_conn":
+		return pool.LeastConnPolicy{}
+	case "ip_hash":
+		return pool.IPHashPolicy{}
+	case "uri_hash":
+		return pool.URIHashPolicy{}
+	case "header_hash":
+		return &pool.HeaderHashPolicy{HeaderName: cfg.HeaderName}
+	case "cookie":
+		return &pool.CookiePolicy{CookieName: cfg.CookieName}
+	case "first":
+		return pool.FirstPolicy{}
+	case "fallback":
+		policies := make([]pool.SelectionPolicy, 0, len(cfg.Fallback))
+
+		for _, t := range cfg.Fallback {
+			policies = append(policies, buildSelectionPolicy(config.PolicyConfig{
+				Type:       t,
+				HeaderName: cfg.HeaderName,
+				CookieName: cfg.CookieName,
+			}))
+		}
+
+		return &pool.FallbackPolicy{Policies: policies}
+	case "round_robin", "":
+		return &pool.RoundRobinPolicy{}
+	default:
+		log.Printf("[Fulcrum] Unknown selection policy %q, defaulting to round_robin", cfg.Type)
+
+		return &pool.RoundRobinPolicy{}
 	}
 }
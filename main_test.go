@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Manpreet-Bhatti/Fulcrum/pool"
+)
+
+// echoUpgradeServer hijacks every request it receives, replies with 101 Switching Protocols, and echoes whatever bytes it
+// reads back to the client, standing in for a real WebSocket backend.
+func echoUpgradeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+
+		if !ok {
+			http.Error(w, "hijack unsupported", http.StatusInternalServerError)
+
+			return
+		}
+
+		conn, rw, err := hijacker.Hijack()
+
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		rw.Flush()
+
+		for {
+			line, err := rw.ReadString('\n')
+
+			if err != nil {
+				return
+			}
+
+			rw.WriteString(line)
+			rw.Flush()
+		}
+	}))
+}
+
+func TestLoadBalancerProxiesWebSocketEcho(t *testing.T) {
+	backend := echoUpgradeServer(t)
+	defer backend.Close()
+
+	backendURL, err := url.Parse(backend.URL)
+
+	if err != nil {
+		t.Fatalf("parse backend URL: %v", err)
+	}
+
+	serverPool := &pool.ServerPool{}
+	proxy := httputil.NewSingleHostReverseProxy(backendURL)
+	proxy.ErrorHandler = newErrorHandler(serverPool, backendURL)
+
+	serverPool.AddBackend(&pool.Backend{
+		Name:      "echo",
+		URL:       backendURL,
+		Transport: pool.NewHTTPTransport(proxy),
+		Alive:     true,
+		Weight:    1,
+	})
+
+	lb := httptest.NewServer(newLoadBalancerHandler(serverPool))
+	defer lb.Close()
+
+	lbURL, err := url.Parse(lb.URL)
+
+	if err != nil {
+		t.Fatalf("parse load balancer URL: %v", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", lbURL.Host, 2*time.Second)
+
+	if err != nil {
+		t.Fatalf("dial load balancer: %v", err)
+	}
+
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: " + lbURL.Host +
+		"\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+
+	if err != nil {
+		t.Fatalf("write handshake: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+
+	if err != nil {
+		t.Fatalf("read handshake response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101 Switching Protocols, got %d", resp.StatusCode)
+	}
+
+	if _, err := conn.Write([]byte("hello fulcrum\n")); err != nil {
+		t.Fatalf("write echo payload: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	line, err := reader.ReadString('\n')
+
+	if err != nil {
+		t.Fatalf("read echo reply: %v", err)
+	}
+
+	if line != "hello fulcrum\n" {
+		t.Fatalf("expected echo of %q, got %q", "hello fulcrum\n", line)
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// LoggingMiddleware logs the method, path, remote address, and duration
+// of every request handled by next.
+func LoggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		next.ServeHTTP(w, r)
+
+		log.Printf("[Fulcrum] %s %s %s (%s)", r.Method, r.URL.Path, r.RemoteAddr, time.Since(start))
+	})
+}
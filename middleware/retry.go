@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/Manpreet-Bhatti/Fulcrum/config"
+)
+
+type retryContextKey string
+
+const spoolCtxKey retryContextKey = "retry-spool"
+
+// DefaultRetryMaxMemMB and DefaultRetryHardCapMB bound the spool used
+// when config.RetryConfig is unset (e.g. a config.json with no "retry"
+// section), so a stock config still bounds how much of a request body
+// is buffered for retry rather than spilling every byte to disk.
+const (
+	DefaultRetryMaxMemMB  int64 = 2
+	DefaultRetryHardCapMB int64 = 20
+)
+
+// RetryableBody tees every request body through a bounded Spool before
+// the rest of the handler chain (and eventually the proxy) consumes it,
+// so that the ErrorHandler can replay it against the next peer via
+// ResetRetryBody instead of sending a drained reader on retry. A zero
+// MaxMem/HardCap (cfg.Retry left unset) falls back to
+// DefaultRetryMaxMemMB/DefaultRetryHardCapMB rather than spooling
+// unboundedly to disk.
+func RetryableBody(cfg config.RetryConfig) func(http.Handler) http.Handler {
+	maxMemMB, hardCapMB := cfg.MaxMem, cfg.HardCap
+
+	if maxMemMB == 0 {
+		maxMemMB = DefaultRetryMaxMemMB
+	}
+
+	if hardCapMB == 0 {
+		hardCapMB = DefaultRetryHardCapMB
+	}
+
+	maxMem := maxMemMB * 1024 * 1024
+	maxBody := hardCapMB * 1024 * 1024
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			spool := NewSpool(maxMem, maxBody)
+			defer spool.Close()
+
+			r.Body = &teeReadCloser{r: r.Body, w: spool}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), spoolCtxKey, spool)))
+		})
+	}
+}
+
+// SpoolFromContext returns the Spool teeing the current request body, if
+// RetryableBody is installed in the handler chain.
+func SpoolFromContext(r *http.Request) (*Spool, bool) {
+	spool, ok := r.Context().Value(spoolCtxKey).(*Spool)
+
+	return spool, ok
+}
+
+// ResetRetryBody rewinds r's body to everything spooled so far, so it
+// can be replayed against the next backend. It returns false, leaving r
+// untouched, when the body grew past the configured hard cap and must
+// not be retried, or when the body was never spooled at all: the tee
+// only captures bytes as the transport reads them, and a RoundTripper
+// that fails to dial (the most common retry trigger) never reads the
+// body, which would otherwise silently replay as empty instead of
+// refusing the retry.
+func ResetRetryBody(r *http.Request) bool {
+	spool, ok := SpoolFromContext(r)
+
+	if !ok {
+		return true
+	}
+
+	if spool.Exceeded() {
+		return false
+	}
+
+	if r.ContentLength != 0 && spool.Written() == 0 {
+		return false
+	}
+
+	reader, err := spool.Reader()
+
+	if err != nil {
+		return false
+	}
+
+	r.Body = reader
+
+	return true
+}
+
+// teeReadCloser copies every byte read from r into w before returning it,
+// so the original body can be reconstructed later from w.
+type teeReadCloser struct {
+	r io.ReadCloser
+	w io.Writer
+}
+
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+
+	if n > 0 {
+		if _, werr := t.w.Write(p[:n]); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
+
+func (t *teeReadCloser) Close() error {
+	return t.r.Close()
+}
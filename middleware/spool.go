@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// Spool buffers a request body so it can be replayed on retry: up to
+// maxMem bytes are kept in memory, with anything past that spilled to a
+// temp file. Once the body grows past maxBody, Spool stops buffering
+// and marks itself Exceeded so the caller knows not to retry.
+type Spool struct {
+	maxMem  int64
+	maxBody int64
+
+	mem      bytes.Buffer
+	overflow *os.File
+	written  int64
+	exceeded bool
+}
+
+// NewSpool returns a Spool that keeps up to maxMem bytes in memory and
+// refuses to buffer past maxBody bytes. A maxBody of 0 disables the hard
+// cap.
+func NewSpool(maxMem, maxBody int64) *Spool {
+	return &Spool{maxMem: maxMem, maxBody: maxBody}
+}
+
+// Write implements io.Writer so a Spool can tee a request body as it's
+// read by the handler.
+func (s *Spool) Write(p []byte) (int, error) {
+	if s.exceeded {
+		return len(p), nil
+	}
+
+	s.written += int64(len(p))
+
+	if s.maxBody > 0 && s.written > s.maxBody {
+		s.exceeded = true
+
+		return len(p), nil
+	}
+
+	if s.overflow == nil && int64(s.mem.Len())+int64(len(p)) <= s.maxMem {
+		return s.mem.Write(p)
+	}
+
+	if s.overflow == nil {
+		f, err := os.CreateTemp("", "fulcrum-retry-spool-*")
+
+		if err != nil {
+			s.exceeded = true
+
+			return len(p), nil
+		}
+
+		s.overflow = f
+	}
+
+	return s.overflow.Write(p)
+}
+
+// Exceeded reports whether the body outgrew maxBody and must not be
+// replayed.
+func (s *Spool) Exceeded() bool {
+	return s.exceeded
+}
+
+// Written reports how many bytes have been teed into the spool so far.
+func (s *Spool) Written() int64 {
+	return s.written
+}
+
+// Reader returns a fresh reader over everything spooled so far, starting
+// from the beginning, for replay on retry.
+func (s *Spool) Reader() (io.ReadCloser, error) {
+	memReader := bytes.NewReader(s.mem.Bytes())
+
+	if s.overflow == nil {
+		return io.NopCloser(memReader), nil
+	}
+
+	if _, err := s.overflow.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(io.MultiReader(memReader, s.overflow)), nil
+}
+
+// Close removes the temp file backing the spool, if one was created.
+// Safe to call even if the body never spilled to disk.
+func (s *Spool) Close() error {
+	if s.overflow == nil {
+		return nil
+	}
+
+	name := s.overflow.Name()
+	err := s.overflow.Close()
+	_ = os.Remove(name)
+
+	return err
+}
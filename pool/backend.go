@@ -1,9 +1,10 @@
 package pool
 
 import (
-	"net/http/httputil"
+	"encoding/json"
 	"net/url"
 	"sync"
+	"time"
 )
 
 type contextKey string
@@ -11,18 +12,87 @@ type contextKey string
 const RetryAttempts int = 3
 const RetryCtxKey contextKey = "retry"
 
+// NoRetryCtxKey marks a request as pinned to its current backend, e.g.
+// because it has been upgraded (WebSocket) and bytes have already
+// started flowing, so it must never be replayed against another peer.
+const NoRetryCtxKey contextKey = "no-retry"
+
+// CircuitState models a backend's circuit breaker lifecycle: Closed
+// serves live traffic normally, Open diverts all traffic for a cooldown
+// window, and HalfOpen admits exactly one health probe to decide whether
+// to close the circuit again or reopen with a longer cooldown.
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+func (s CircuitState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
 type Backend struct {
-	Name                string
-	URL                 *url.URL
-	ReverseProxy        *httputil.ReverseProxy `json:"-"`
+	Name string
+	URL  *url.URL
+
+	// Transport forwards requests to this backend, e.g. over HTTP
+	// (httpTransport) or FastCGI (fastcgiTransport).
+	Transport           Transport    `json:"-"`
 	Alive               bool
+	Weight              int
 	Mux                 sync.RWMutex `json:"-"`
 	ActiveConnections   int64
+	ActiveUpgrades      uint64
 	TotalRequests       uint64
 	FailedRequests      uint64
 	ConsecutiveFailures uint64
+
+	// Probe is the active health check this backend is probed with.
+	// Nil falls back to a plain TCP dial.
+	Probe         HealthProbe   `json:"-"`
+	ProbeInterval time.Duration `json:"-"`
+	MaxCooldown   time.Duration `json:"-"`
+
+	CircuitState  CircuitState
+	cooldown      time.Duration
+	openedAt      time.Time
+	probeAdmitted bool
+
+	LastProbeAt      time.Time
+	LastProbeLatency time.Duration
+	LastProbeError   string
+
+	// StatusCounts tallies completed (non-upgrade) requests by response
+	// status code, for the /metrics requests_total series. Guarded by Mux.
+	StatusCounts map[int]uint64
+
+	// Latency records completed-request durations for the /metrics
+	// request_duration_seconds histogram. Nil on a Backend built without
+	// one, in which case no latency is recorded.
+	Latency *Histogram `json:"-"`
+
+	// Draining marks a backend as scheduled for removal: every
+	// SelectionPolicy must treat it as ineligible for new requests, but
+	// its existing ActiveConnections are left to finish naturally.
+	Draining bool
 }
 
+// SetAlive flips whether the backend is eligible for live traffic. Most
+// callers should prefer RecordCircuitSuccess/RecordCircuitFailure, which
+// keep the circuit breaker state machine in sync with this flag.
 func (backend *Backend) SetAlive(alive bool) {
 	backend.Mux.Lock()
 	backend.Alive = alive
@@ -32,5 +102,104 @@ func (backend *Backend) SetAlive(alive bool) {
 func (backend *Backend) IsAlive() bool {
 	backend.Mux.RLock()
 	defer backend.Mux.RUnlock()
+
 	return backend.Alive
 }
+
+// SetDraining marks the backend as scheduled for removal (or brings it
+// back into rotation if draining is false). A draining backend is
+// ineligible for new requests but keeps serving the ones already in
+// flight.
+func (backend *Backend) SetDraining(draining bool) {
+	backend.Mux.Lock()
+	backend.Draining = draining
+	backend.Mux.Unlock()
+}
+
+// IsDraining reports whether the backend is scheduled for removal.
+func (backend *Backend) IsDraining() bool {
+	backend.Mux.RLock()
+	defer backend.Mux.RUnlock()
+
+	return backend.Draining
+}
+
+// RecordCircuitSuccess closes the circuit, marks the backend alive, and
+// resets the backoff.
+func (backend *Backend) RecordCircuitSuccess() {
+	backend.Mux.Lock()
+	defer backend.Mux.Unlock()
+
+	backend.CircuitState = CircuitClosed
+	backend.Alive = true
+	backend.cooldown = 0
+}
+
+// RecordCircuitFailure opens the circuit and marks the backend down,
+// doubling the cooldown each time it reopens (starting at one second,
+// capped at maxCooldown).
+func (backend *Backend) RecordCircuitFailure(maxCooldown time.Duration) {
+	backend.Mux.Lock()
+	defer backend.Mux.Unlock()
+
+	if backend.cooldown <= 0 {
+		backend.cooldown = time.Second
+	} else {
+		backend.cooldown *= 2
+	}
+
+	if maxCooldown > 0 && backend.cooldown > maxCooldown {
+		backend.cooldown = maxCooldown
+	}
+
+	backend.CircuitState = CircuitOpen
+	backend.openedAt = time.Now()
+	backend.Alive = false
+}
+
+// ShouldProbe reports whether the active health checker should send a
+// probe right now: always while Closed, and exactly once per HalfOpen
+// window. An Open circuit whose cooldown has elapsed is flipped to
+// HalfOpen as a side effect; while the cooldown hasn't elapsed, or the
+// HalfOpen window's single probe has already been sent, it returns
+// false.
+func (backend *Backend) ShouldProbe() bool {
+	backend.Mux.Lock()
+	defer backend.Mux.Unlock()
+
+	if backend.CircuitState == CircuitClosed {
+		return true
+	}
+
+	if backend.CircuitState == CircuitOpen && time.Since(backend.openedAt) >= backend.cooldown {
+		backend.CircuitState = CircuitHalfOpen
+		backend.probeAdmitted = false
+	}
+
+	if backend.CircuitState != CircuitHalfOpen || backend.probeAdmitted {
+		return false
+	}
+
+	backend.probeAdmitted = true
+
+	return true
+}
+
+// RecordRequestMetrics records the outcome of one completed (non-upgrade)
+// request against this backend: statusCode feeds the
+// fulcrum_backend_requests_total series, and duration feeds the
+// fulcrum_backend_request_duration_seconds histogram.
+func (backend *Backend) RecordRequestMetrics(statusCode int, duration time.Duration) {
+	backend.Mux.Lock()
+
+	if backend.StatusCounts == nil {
+		backend.StatusCounts = make(map[int]uint64)
+	}
+
+	backend.StatusCounts[statusCode]++
+	backend.Mux.Unlock()
+
+	if backend.Latency != nil {
+		backend.Latency.Observe(duration.Seconds())
+	}
+}
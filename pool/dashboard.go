@@ -8,9 +8,11 @@ import (
 )
 
 func (s *ServerPool) ServeDashboard(w http.ResponseWriter, r *http.Request) {
+	backends := s.AllBackends()
+
 	if r.URL.Query().Get("format") == "json" {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(s.Backends)
+		json.NewEncoder(w).Encode(backends)
 
 		return
 	}
@@ -59,15 +61,7 @@ func (s *ServerPool) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 		
 		<div class="grid">`
 
-	seen := make(map[string]bool)
-
-	for _, backend := range s.Backends {
-		if seen[backend.URL.String()] {
-			continue
-		}
-
-		seen[backend.URL.String()] = true
-
+	for _, backend := range backends {
 		alive := backend.IsAlive()
 		statusBadge := "<span class='badge up'>ONLINE</span>"
 
@@ -84,13 +78,25 @@ func (s *ServerPool) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 			errorRate = (float64(failed) / float64(total)) * 100
 		}
 
+		backend.Mux.RLock()
+		circuitState := backend.CircuitState.String()
+		lastProbeLatency := backend.LastProbeLatency
+		lastProbeError := backend.LastProbeError
+		backend.Mux.RUnlock()
+
+		probeLine := fmt.Sprintf("probe: %s", lastProbeLatency)
+
+		if lastProbeError != "" {
+			probeLine = fmt.Sprintf("probe: %s", lastProbeError)
+		}
+
 		html += fmt.Sprintf(`
 			<div class="card">
 				<div class="header">
 					<span class="url">%s</span>
 					%s
 				</div>
-				<div style="font-size: 0.8em; color: #94a3b8; margin-bottom: 15px;">%s</div>
+				<div style="font-size: 0.8em; color: #94a3b8; margin-bottom: 15px;">%s &middot; circuit: %s &middot; %s</div>
 				<div class="stats">
 					<div class="stat-item">
 						<span class="label">Active Conns</span>
@@ -110,7 +116,7 @@ func (s *ServerPool) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 					</div>
 				</div>
 			</div>
-		`, backend.Name, statusBadge, backend.URL, active, total, failed, errorRate)
+		`, backend.Name, statusBadge, backend.URL, circuitState, probeLine, active, total, failed, errorRate)
 	}
 
 	html += `</div>
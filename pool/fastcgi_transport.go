@@ -0,0 +1,139 @@
+package pool
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Manpreet-Bhatti/Fulcrum/fastcgi"
+)
+
+// FastCGIConfig configures a fastcgiTransport: where the FastCGI
+// application listens, the document root PHP/Python scripts resolve
+// against, the index script for directory requests, and the regex used
+// to split SCRIPT_NAME from PATH_INFO for routes like
+// /index.php/extra/path.
+type FastCGIConfig struct {
+	Network   string // "tcp" or "unix"
+	Address   string // host:port, or socket path
+	Root      string
+	Index     string
+	SplitPath *regexp.Regexp
+	Env       map[string]string
+	Timeout   time.Duration
+}
+
+// fastcgiTransport forwards requests to a backend by speaking FastCGI
+// over Network/Address instead of HTTP.
+type fastcgiTransport struct {
+	cfg            FastCGIConfig
+	errorHandler   func(http.ResponseWriter, *http.Request, error)
+	modifyResponse func(*http.Response) error
+}
+
+// NewFastCGITransport returns a Transport that speaks FastCGI to the
+// application described by cfg. errorHandler runs on dial/protocol
+// failures and modifyResponse on every response that comes back, so the
+// same circuit-breaker accounting used for HTTP backends applies here
+// too.
+func NewFastCGITransport(cfg FastCGIConfig, errorHandler func(http.ResponseWriter, *http.Request, error), modifyResponse func(*http.Response) error) Transport {
+	return &fastcgiTransport{cfg: cfg, errorHandler: errorHandler, modifyResponse: modifyResponse}
+}
+
+func (t *fastcgiTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := fastcgi.Do(t.cfg.Network, t.cfg.Address, t.cfg.Timeout, t.buildParams(r), r.Body)
+
+	if err != nil {
+		t.errorHandler(w, r, err)
+
+		return
+	}
+
+	defer resp.Body.Close()
+
+	if t.modifyResponse != nil {
+		if err := t.modifyResponse(resp); err != nil {
+			t.errorHandler(w, r, err)
+
+			return
+		}
+	}
+
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// buildParams translates r into the CGI-style parameters a FastCGI
+// application expects.
+func (t *fastcgiTransport) buildParams(r *http.Request) map[string]string {
+	scriptName, pathInfo := t.splitPath(r.URL.Path)
+
+	params := map[string]string{
+		"SCRIPT_FILENAME":   t.cfg.Root + scriptName,
+		"SCRIPT_NAME":       scriptName,
+		"PATH_INFO":         pathInfo,
+		"QUERY_STRING":      r.URL.RawQuery,
+		"REQUEST_METHOD":    r.Method,
+		"SERVER_PROTOCOL":   r.Proto,
+		"GATEWAY_INTERFACE": "CGI/1.1",
+		"SERVER_SOFTWARE":   "Fulcrum",
+		"CONTENT_TYPE":      r.Header.Get("Content-Type"),
+		"CONTENT_LENGTH":    strconv.FormatInt(r.ContentLength, 10),
+	}
+
+	if remoteAddr, remotePort, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		params["REMOTE_ADDR"] = remoteAddr
+		params["REMOTE_PORT"] = remotePort
+	} else {
+		params["REMOTE_ADDR"] = r.RemoteAddr
+	}
+
+	if r.TLS != nil {
+		params["HTTPS"] = "on"
+	}
+
+	for k, v := range t.cfg.Env {
+		params[k] = v
+	}
+
+	return params
+}
+
+// splitPath separates the request path into a SCRIPT_NAME (the part
+// that resolves to a script under Root) and a PATH_INFO (anything
+// trailing it), using SplitPath if configured, and appending Index when
+// the request targets a directory.
+func (t *fastcgiTransport) splitPath(reqPath string) (scriptName, pathInfo string) {
+	scriptName = reqPath
+
+	if t.cfg.SplitPath != nil {
+		if loc := t.cfg.SplitPath.FindStringIndex(reqPath); loc != nil {
+			scriptName = reqPath[:loc[1]]
+			pathInfo = reqPath[loc[1]:]
+		}
+	}
+
+	if scriptName == "" || strings.HasSuffix(scriptName, "/") {
+		scriptName += t.index()
+	}
+
+	return scriptName, pathInfo
+}
+
+func (t *fastcgiTransport) index() string {
+	if t.cfg.Index != "" {
+		return t.cfg.Index
+	}
+
+	return "index.php"
+}
@@ -0,0 +1,43 @@
+package pool
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// HijackTrackingWriter wraps a ResponseWriter so callers can tell, after
+// the fact, whether the underlying connection was hijacked, as happens
+// when the reverse proxy completes a WebSocket/Upgrade handshake. Once a
+// connection is hijacked, writing an HTTP response to it corrupts the
+// stream, so error handlers must check Hijacked() before falling back to
+// an error body.
+type HijackTrackingWriter struct {
+	http.ResponseWriter
+
+	hijacked bool
+}
+
+// Hijack implements http.Hijacker, delegating to the wrapped writer and
+// recording whether the connection left HTTP's hands.
+func (h *HijackTrackingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := h.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, fmt.Errorf("pool: underlying ResponseWriter does not support hijacking")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+
+	if err == nil {
+		h.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+// Hijacked reports whether Hijack succeeded on this writer.
+func (h *HijackTrackingWriter) Hijacked() bool {
+	return h.hijacked
+}
@@ -0,0 +1,61 @@
+package pool
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultHistogramBuckets are the request-duration bucket boundaries (in
+// seconds) used when config.MetricsConfig.Buckets is empty.
+var DefaultHistogramBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram is a cumulative latency histogram over a fixed set of bucket
+// boundaries, matching Prometheus histogram semantics: each bucket counts
+// every observation less than or equal to its boundary.
+type Histogram struct {
+	buckets []float64
+
+	mux    sync.Mutex
+	counts []uint64
+	sum    float64
+	total  uint64
+}
+
+// NewHistogram returns a Histogram over buckets, sorted ascending.
+// Buckets falls back to DefaultHistogramBuckets if empty.
+func NewHistogram(buckets []float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultHistogramBuckets
+	}
+
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &Histogram{buckets: sorted, counts: make([]uint64, len(sorted))}
+}
+
+// Observe records a single duration, in seconds.
+func (h *Histogram) Observe(seconds float64) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	for i, boundary := range h.buckets {
+		if seconds <= boundary {
+			h.counts[i]++
+		}
+	}
+
+	h.total++
+	h.sum += seconds
+}
+
+// Snapshot returns the histogram's bucket boundaries, their cumulative
+// counts, and the running sum/total observation count.
+func (h *Histogram) Snapshot() (buckets []float64, counts []uint64, sum float64, total uint64) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	counts = append([]uint64(nil), h.counts...)
+
+	return h.buckets, counts, h.sum, h.total
+}
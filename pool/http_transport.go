@@ -0,0 +1,21 @@
+package pool
+
+import (
+	"net/http"
+	"net/http/httputil"
+)
+
+// httpTransport forwards requests to a backend via a standard HTTP
+// reverse proxy.
+type httpTransport struct {
+	proxy *httputil.ReverseProxy
+}
+
+// NewHTTPTransport wraps proxy as a Transport.
+func NewHTTPTransport(proxy *httputil.ReverseProxy) Transport {
+	return &httpTransport{proxy: proxy}
+}
+
+func (t *httpTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,112 @@
+package pool
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+)
+
+// ServeMetrics renders s's backends in Prometheus text exposition format,
+// for mounting on the :8081 dashboard mux alongside ServeDashboard.
+func (s *ServerPool) ServeMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	s.mux.RLock()
+	backends := append([]*Backend(nil), s.Backends...)
+	s.mux.RUnlock()
+
+	fmt.Fprintln(w, "# HELP fulcrum_backend_up Whether the backend is currently eligible for traffic.")
+	fmt.Fprintln(w, "# TYPE fulcrum_backend_up gauge")
+
+	for _, b := range backends {
+		fmt.Fprintf(w, "fulcrum_backend_up{backend=%q} %d\n", b.Name, boolToInt(b.IsAlive()))
+	}
+
+	fmt.Fprintln(w, "# HELP fulcrum_backend_requests_total Requests completed per backend and status code.")
+	fmt.Fprintln(w, "# TYPE fulcrum_backend_requests_total counter")
+
+	for _, b := range backends {
+		b.Mux.RLock()
+		counts := make(map[int]uint64, len(b.StatusCounts))
+
+		for code, n := range b.StatusCounts {
+			counts[code] = n
+		}
+
+		b.Mux.RUnlock()
+
+		codes := make([]int, 0, len(counts))
+
+		for code := range counts {
+			codes = append(codes, code)
+		}
+
+		sort.Ints(codes)
+
+		for _, code := range codes {
+			fmt.Fprintf(w, "fulcrum_backend_requests_total{backend=%q,code=%q} %d\n", b.Name, strconv.Itoa(code), counts[code])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP fulcrum_backend_request_duration_seconds Completed request latency per backend.")
+	fmt.Fprintln(w, "# TYPE fulcrum_backend_request_duration_seconds histogram")
+
+	for _, b := range backends {
+		if b.Latency == nil {
+			continue
+		}
+
+		boundaries, counts, sum, total := b.Latency.Snapshot()
+
+		for i, boundary := range boundaries {
+			fmt.Fprintf(w, "fulcrum_backend_request_duration_seconds_bucket{backend=%q,le=%q} %d\n", b.Name, strconv.FormatFloat(boundary, 'g', -1, 64), counts[i])
+		}
+
+		fmt.Fprintf(w, "fulcrum_backend_request_duration_seconds_bucket{backend=%q,le=\"+Inf\"} %d\n", b.Name, total)
+		fmt.Fprintf(w, "fulcrum_backend_request_duration_seconds_sum{backend=%q} %g\n", b.Name, sum)
+		fmt.Fprintf(w, "fulcrum_backend_request_duration_seconds_count{backend=%q} %d\n", b.Name, total)
+	}
+
+	fmt.Fprintln(w, "# HELP fulcrum_backend_active_connections In-flight requests per backend.")
+	fmt.Fprintln(w, "# TYPE fulcrum_backend_active_connections gauge")
+
+	for _, b := range backends {
+		fmt.Fprintf(w, "fulcrum_backend_active_connections{backend=%q} %d\n", b.Name, atomic.LoadInt64(&b.ActiveConnections))
+	}
+
+	fmt.Fprintln(w, "# HELP fulcrum_circuit_state Circuit breaker state per backend (0=closed,1=half_open,2=open).")
+	fmt.Fprintln(w, "# TYPE fulcrum_circuit_state gauge")
+
+	for _, b := range backends {
+		b.Mux.RLock()
+		state := b.CircuitState
+		b.Mux.RUnlock()
+
+		fmt.Fprintf(w, "fulcrum_circuit_state{backend=%q} %d\n", b.Name, circuitStateValue(state))
+	}
+
+	fmt.Fprintln(w, "# HELP fulcrum_retries_total Total number of requests retried against a different backend.")
+	fmt.Fprintln(w, "# TYPE fulcrum_retries_total counter")
+	fmt.Fprintf(w, "fulcrum_retries_total %d\n", atomic.LoadUint64(&s.Retries))
+}
+
+func circuitStateValue(state CircuitState) int {
+	switch state {
+	case CircuitHalfOpen:
+		return 1
+	case CircuitOpen:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
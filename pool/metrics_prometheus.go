@@ -0,0 +1,60 @@
+//go:build fulcrum_prometheus
+
+package pool
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// This file only builds with the fulcrum_prometheus tag, so Fulcrum stays
+// zero-dependency by default. Build with
+// `go build -tags fulcrum_prometheus` (and `go get
+// github.com/prometheus/client_golang`) to use PrometheusCollector instead
+// of, or alongside, the built-in ServeMetrics text endpoint.
+
+var (
+	backendUpDesc          = prometheus.NewDesc("fulcrum_backend_up", "Whether the backend is currently eligible for traffic.", []string{"backend"}, nil)
+	backendActiveConnsDesc = prometheus.NewDesc("fulcrum_backend_active_connections", "In-flight requests per backend.", []string{"backend"}, nil)
+	circuitStateDesc       = prometheus.NewDesc("fulcrum_circuit_state", "Circuit breaker state per backend (0=closed,1=half_open,2=open).", []string{"backend"}, nil)
+	retriesTotalDesc       = prometheus.NewDesc("fulcrum_retries_total", "Total number of requests retried against a different backend.", nil, nil)
+)
+
+// PrometheusCollector adapts a ServerPool into a prometheus.Collector for
+// users who'd rather register it with a prometheus.Registry and serve it
+// via promhttp than scrape the built-in ServeMetrics text endpoint.
+type PrometheusCollector struct {
+	pool *ServerPool
+}
+
+// NewPrometheusCollector returns a prometheus.Collector for pool.
+func NewPrometheusCollector(pool *ServerPool) *PrometheusCollector {
+	return &PrometheusCollector{pool: pool}
+}
+
+func (c *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- backendUpDesc
+	ch <- backendActiveConnsDesc
+	ch <- circuitStateDesc
+	ch <- retriesTotalDesc
+}
+
+func (c *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	c.pool.mux.RLock()
+	backends := append([]*Backend(nil), c.pool.Backends...)
+	c.pool.mux.RUnlock()
+
+	for _, b := range backends {
+		ch <- prometheus.MustNewConstMetric(backendUpDesc, prometheus.GaugeValue, float64(boolToInt(b.IsAlive())), b.Name)
+		ch <- prometheus.MustNewConstMetric(backendActiveConnsDesc, prometheus.GaugeValue, float64(atomic.LoadInt64(&b.ActiveConnections)), b.Name)
+
+		b.Mux.RLock()
+		state := b.CircuitState
+		b.Mux.RUnlock()
+
+		ch <- prometheus.MustNewConstMetric(circuitStateDesc, prometheus.GaugeValue, float64(circuitStateValue(state)), b.Name)
+	}
+
+	ch <- prometheus.MustNewConstMetric(retriesTotalDesc, prometheus.CounterValue, float64(atomic.LoadUint64(&c.pool.Retries)))
+}
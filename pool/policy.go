@@ -0,0 +1,248 @@
+package pool
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// SelectionPolicy chooses the backend that should serve r from pool.
+// Select must skip unhealthy backends and return nil when no backend is
+// eligible.
+type SelectionPolicy interface {
+	Select(pool *ServerPool, r *http.Request) *Backend
+}
+
+// CookieAware is implemented by policies that need to pin the response to
+// whichever backend they selected, e.g. sticky sessions.
+type CookieAware interface {
+	SetStickyCookie(w http.ResponseWriter, backend *Backend)
+}
+
+// RoundRobinPolicy cycles through the alive backends in order.
+type RoundRobinPolicy struct {
+	counter uint64
+}
+
+func (p *RoundRobinPolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	alive := pool.AliveBackends()
+
+	if len(alive) == 0 {
+		return nil
+	}
+
+	idx := atomic.AddUint64(&p.counter, 1)
+
+	return alive[idx%uint64(len(alive))]
+}
+
+// WeightedRoundRobinPolicy implements smooth weighted round robin: each
+// backend accrues its Weight every round, and the backend with the
+// highest accrued weight is chosen and then discounted by the total
+// weight. This spreads picks proportionally to Weight without ever
+// bursting requests at one backend, unlike naive deficit RR.
+type WeightedRoundRobinPolicy struct {
+	mux            sync.Mutex
+	currentWeights map[*Backend]int
+}
+
+func (p *WeightedRoundRobinPolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	alive := pool.AliveBackends()
+
+	if len(alive) == 0 {
+		return nil
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.currentWeights == nil {
+		p.currentWeights = make(map[*Backend]int)
+	}
+
+	total := 0
+	var best *Backend
+
+	for _, b := range alive {
+		weight := b.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+
+		p.currentWeights[b] += weight
+		total += weight
+
+		if best == nil || p.currentWeights[b] > p.currentWeights[best] {
+			best = b
+		}
+	}
+
+	p.currentWeights[best] -= total
+
+	return best
+}
+
+// RandomPolicy picks uniformly among the alive backends.
+type RandomPolicy struct{}
+
+func (RandomPolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	alive := pool.AliveBackends()
+
+	if len(alive) == 0 {
+		return nil
+	}
+
+	return alive[rand.Intn(len(alive))]
+}
+
+// LeastConnPolicy picks the alive backend with the fewest active
+// connections.
+type LeastConnPolicy struct{}
+
+func (LeastConnPolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	alive := pool.AliveBackends()
+
+	var best *Backend
+
+	for _, b := range alive {
+		if best == nil || atomic.LoadInt64(&b.ActiveConnections) < atomic.LoadInt64(&best.ActiveConnections) {
+			best = b
+		}
+	}
+
+	return best
+}
+
+// IPHashPolicy picks a backend from a hash of r.RemoteAddr (client host
+// and port), so requests with the same RemoteAddr land on the same
+// backend as long as the set of alive backends does not change.
+type IPHashPolicy struct{}
+
+func (IPHashPolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	alive := pool.AliveBackends()
+
+	if len(alive) == 0 {
+		return nil
+	}
+
+	return alive[hashString(r.RemoteAddr)%uint32(len(alive))]
+}
+
+// URIHashPolicy pins a request to a backend based on a hash of the
+// request URI, useful for cache-friendly fan-out to origin backends.
+type URIHashPolicy struct{}
+
+func (URIHashPolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	alive := pool.AliveBackends()
+
+	if len(alive) == 0 {
+		return nil
+	}
+
+	return alive[hashString(r.URL.RequestURI())%uint32(len(alive))]
+}
+
+// HeaderHashPolicy pins a request to a backend based on a hash of a
+// configurable request header.
+type HeaderHashPolicy struct {
+	HeaderName string
+}
+
+func (p *HeaderHashPolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	alive := pool.AliveBackends()
+
+	if len(alive) == 0 {
+		return nil
+	}
+
+	return alive[hashString(r.Header.Get(p.HeaderName))%uint32(len(alive))]
+}
+
+// CookiePolicy implements sticky sessions: a client is pinned to the
+// backend named in CookieName. If there is no cookie, or the pinned
+// backend has gone down, Select returns nil so that a FallbackPolicy can
+// choose a replacement backend for SetStickyCookie to re-pin to.
+type CookiePolicy struct {
+	CookieName string
+}
+
+func (p *CookiePolicy) cookieName() string {
+	if p.CookieName == "" {
+		return "FULCRUM_BACKEND"
+	}
+
+	return p.CookieName
+}
+
+func (p *CookiePolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	c, err := r.Cookie(p.cookieName())
+
+	if err != nil {
+		return nil
+	}
+
+	if b := pool.GetBackendByName(c.Value); b != nil && b.IsAlive() && !b.IsDraining() {
+		return b
+	}
+
+	return nil
+}
+
+func (p *CookiePolicy) SetStickyCookie(w http.ResponseWriter, backend *Backend) {
+	http.SetCookie(w, &http.Cookie{
+		Name:  p.cookieName(),
+		Value: backend.Name,
+		Path:  "/",
+	})
+}
+
+// FirstPolicy always picks the first healthy backend in registration
+// order.
+type FirstPolicy struct{}
+
+func (FirstPolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	pool.mux.RLock()
+	defer pool.mux.RUnlock()
+
+	for _, b := range pool.Backends {
+		if b.IsAlive() && !b.IsDraining() {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// FallbackPolicy tries each policy in order and returns the first
+// non-nil pick, e.g. "cookie -> least_conn" to re-pin sessions whose
+// backend went down onto a freshly chosen one.
+type FallbackPolicy struct {
+	Policies []SelectionPolicy
+}
+
+func (p *FallbackPolicy) Select(pool *ServerPool, r *http.Request) *Backend {
+	for _, policy := range p.Policies {
+		if b := policy.Select(pool, r); b != nil {
+			return b
+		}
+	}
+
+	return nil
+}
+
+func (p *FallbackPolicy) SetStickyCookie(w http.ResponseWriter, backend *Backend) {
+	for _, policy := range p.Policies {
+		if ca, ok := policy.(CookieAware); ok {
+			ca.SetStickyCookie(w, backend)
+		}
+	}
+}
+
+func hashString(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+
+	return h.Sum32()
+}
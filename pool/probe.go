@@ -0,0 +1,142 @@
+package pool
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// HealthProbe exercises a backend's health check and reports whether it
+// is healthy.
+type HealthProbe interface {
+	Probe(backend *Backend) error
+}
+
+// HTTPProbe checks backend health with an HTTP GET against Path,
+// succeeding when the response status falls within
+// [ExpectStatusMin, ExpectStatusMax] (default 200-399).
+type HTTPProbe struct {
+	Path            string
+	Timeout         time.Duration
+	ExpectStatusMin int
+	ExpectStatusMax int
+}
+
+func (p *HTTPProbe) Probe(backend *Backend) error {
+	resp, err := p.get(backend)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	return p.checkStatus(resp.StatusCode)
+}
+
+func (p *HTTPProbe) get(backend *Backend) (*http.Response, error) {
+	client := http.Client{Timeout: p.timeout()}
+
+	u := *backend.URL
+	u.Path = p.Path
+
+	return client.Get(u.String())
+}
+
+func (p *HTTPProbe) timeout() time.Duration {
+	if p.Timeout <= 0 {
+		return 2 * time.Second
+	}
+
+	return p.Timeout
+}
+
+func (p *HTTPProbe) checkStatus(status int) error {
+	min, max := p.ExpectStatusMin, p.ExpectStatusMax
+
+	if min == 0 && max == 0 {
+		min, max = 200, 399
+	}
+
+	if status < min || status > max {
+		return fmt.Errorf("unexpected status %d", status)
+	}
+
+	return nil
+}
+
+// BodyMatchProbe extends HTTPProbe with a regex match against the
+// response body, for backends whose health endpoint returns 200 even
+// when degraded.
+type BodyMatchProbe struct {
+	HTTPProbe
+	Expect *regexp.Regexp
+}
+
+func (p *BodyMatchProbe) Probe(backend *Backend) error {
+	resp, err := p.get(backend)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return err
+	}
+
+	if err := p.checkStatus(resp.StatusCode); err != nil {
+		return err
+	}
+
+	if p.Expect != nil && !p.Expect.Match(body) {
+		return fmt.Errorf("response body did not match expected pattern")
+	}
+
+	return nil
+}
+
+// TCPProbe succeeds if it can open a raw connection to the backend. This
+// is the default probe when a backend doesn't configure one.
+type TCPProbe struct {
+	Timeout time.Duration
+}
+
+func (p *TCPProbe) Probe(backend *Backend) error {
+	timeout := p.Timeout
+
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+
+	network, address := dialNetworkAddress(backend.URL)
+
+	conn, err := net.DialTimeout(network, address, timeout)
+
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// dialNetworkAddress translates a backend URL into the network/address
+// pair to dial: a "unix" scheme dials u.Path as a socket (e.g. a
+// FastCGI backend fronting PHP-FPM over a unix socket), anything else
+// dials u.Host over TCP. Mirrors the scheme handling main.go's
+// buildFastCGIConfig uses to build the same backend's transport.
+func dialNetworkAddress(u *url.URL) (network, address string) {
+	if u.Scheme == "unix" {
+		return "unix", u.Path
+	}
+
+	return "tcp", u.Host
+}
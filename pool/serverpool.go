@@ -0,0 +1,236 @@
+package pool
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ServerPool tracks the set of backends behind a single listener and
+// delegates peer selection to a pluggable SelectionPolicy.
+type ServerPool struct {
+	Backends []*Backend
+	Policy   SelectionPolicy
+
+	// Retries counts requests that were replayed against a different
+	// backend after the first one failed, for fulcrum_retries_total.
+	Retries uint64
+
+	// defaultPolicy is the round robin NextPeer uses when Policy is nil.
+	defaultPolicy RoundRobinPolicy
+
+	mux sync.RWMutex
+}
+
+// RecordRetry increments the retries_total counter. Called whenever a
+// failed request is replayed against a different backend.
+func (s *ServerPool) RecordRetry() {
+	atomic.AddUint64(&s.Retries, 1)
+}
+
+// AddBackend registers a backend with the pool.
+func (s *ServerPool) AddBackend(backend *Backend) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.Backends = append(s.Backends, backend)
+}
+
+// GetBackend returns the backend whose URL matches u, or nil if none is
+// registered.
+func (s *ServerPool) GetBackend(u *url.URL) *Backend {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	for _, b := range s.Backends {
+		if b.URL.String() == u.String() {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// GetBackendByName returns the backend with the given name, or nil if none
+// is registered. Used by sticky policies that pin clients by name rather
+// than by URL.
+func (s *ServerPool) GetBackendByName(name string) *Backend {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	for _, b := range s.Backends {
+		if b.Name == name {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// MarkBackendStatus flips the alive flag for the backend matching u.
+func (s *ServerPool) MarkBackendStatus(u *url.URL, alive bool) {
+	if b := s.GetBackend(u); b != nil {
+		b.SetAlive(alive)
+	}
+}
+
+// AliveBackends returns a snapshot of the backends currently eligible for
+// new requests: healthy and not draining.
+func (s *ServerPool) AliveBackends() []*Backend {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	alive := make([]*Backend, 0, len(s.Backends))
+
+	for _, b := range s.Backends {
+		if b.IsAlive() && !b.IsDraining() {
+			alive = append(alive, b)
+		}
+	}
+
+	return alive
+}
+
+// AllBackends returns a snapshot of every registered backend, alive or
+// not. Callers that used to range over Backends directly (safe back when
+// the slice was populated once at startup) must use this instead now
+// that AddBackend/RemoveBackend/Reload mutate it at runtime.
+func (s *ServerPool) AllBackends() []*Backend {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	return append([]*Backend(nil), s.Backends...)
+}
+
+// RemoveBackend removes the named backend from the pool immediately.
+// Callers should drain it first (SetDraining, then wait for
+// ActiveConnections to fall to zero) so in-flight requests aren't cut
+// off.
+func (s *ServerPool) RemoveBackend(name string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for i, b := range s.Backends {
+		if b.Name == name {
+			s.Backends = append(s.Backends[:i], s.Backends[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextPeer selects the next backend to serve r according to the pool's
+// configured SelectionPolicy. Falls back to plain round robin if no
+// policy was configured.
+func (s *ServerPool) NextPeer(r *http.Request) *Backend {
+	policy := s.Policy
+
+	if policy == nil {
+		policy = &s.defaultPolicy
+	}
+
+	return policy.Select(s, r)
+}
+
+// StartHealthCheck runs each backend's configured HealthProbe (a plain
+// TCP dial if none was configured) on its own interval, driving the
+// backend's circuit breaker from the outcome.
+func (s *ServerPool) StartHealthCheck() {
+	s.mux.RLock()
+	backends := append([]*Backend(nil), s.Backends...)
+	s.mux.RUnlock()
+
+	for _, b := range backends {
+		go runProbeLoop(b)
+	}
+}
+
+// StartBackendHealthCheck starts the active health check loop for a
+// single backend, e.g. one added after startup via hot reload or the
+// admin API. Backends present at StartHealthCheck time don't need this.
+func (s *ServerPool) StartBackendHealthCheck(b *Backend) {
+	go runProbeLoop(b)
+}
+
+// ProbeOnce runs b's health check synchronously once, updating its
+// alive/circuit state from the outcome. Used to vet a backend added via
+// hot reload or the admin API before it's eligible for selection.
+func ProbeOnce(b *Backend) {
+	probeBackend(b)
+}
+
+// runProbeLoop ticks probeBackend on b.ProbeInterval for as long as the
+// process runs. ProbeInterval is re-read after every tick, so a reload
+// that changes health.interval for an already-running backend changes
+// this ticker's period from the next tick on.
+func runProbeLoop(b *Backend) {
+	interval := probeInterval(b)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		probeBackend(b)
+
+		if next := probeInterval(b); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+	}
+}
+
+func probeInterval(b *Backend) time.Duration {
+	b.Mux.RLock()
+	interval := b.ProbeInterval
+	b.Mux.RUnlock()
+
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	return interval
+}
+
+func probeBackend(b *Backend) {
+	if !b.ShouldProbe() {
+		return
+	}
+
+	b.Mux.RLock()
+	probe := b.Probe
+	b.Mux.RUnlock()
+
+	if probe == nil {
+		probe = &TCPProbe{}
+	}
+
+	start := time.Now()
+	err := probe.Probe(b)
+	latency := time.Since(start)
+
+	b.Mux.Lock()
+	b.LastProbeAt = start
+	b.LastProbeLatency = latency
+
+	if err != nil {
+		b.LastProbeError = err.Error()
+	} else {
+		b.LastProbeError = ""
+	}
+
+	b.Mux.Unlock()
+
+	if err != nil {
+		log.Printf("[Fulcrum] Health probe failed for %s: %v", b.URL, err)
+		b.RecordCircuitFailure(b.MaxCooldown)
+
+		return
+	}
+
+	b.RecordCircuitSuccess()
+}
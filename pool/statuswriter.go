@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// StatusWriter wraps an http.ResponseWriter to capture the status code a
+// handler wrote, so the load balancer handler can record per-backend
+// metrics at the boundary where it has the full request/response in
+// scope, without every Transport needing to report its own status back.
+type StatusWriter struct {
+	http.ResponseWriter
+
+	Status int
+
+	// Backend records which backend actually produced the response,
+	// letting the error handler update it when a request is retried
+	// against a different peer than the one the caller originally
+	// dispatched to. Nil means the original peer served it.
+	Backend *Backend
+}
+
+// WriteHeader records the first status code written, matching
+// http.ResponseWriter's write-once semantics, then delegates.
+func (w *StatusWriter) WriteHeader(status int) {
+	if w.Status == 0 {
+		w.Status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records an implicit 200 if no header was written yet, matching
+// http.ResponseWriter's behavior, then delegates.
+func (w *StatusWriter) Write(b []byte) (int, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack implements http.Hijacker by delegating to the embedded
+// ResponseWriter, so wrapping a writer in StatusWriter doesn't break a
+// hijack performed by a writer further down the chain (e.g. an upgraded
+// WebSocket connection behind a HijackTrackingWriter).
+func (w *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, fmt.Errorf("pool: underlying ResponseWriter does not support hijacking")
+	}
+
+	return hijacker.Hijack()
+}
+
+// Flush implements http.Flusher by delegating to the embedded
+// ResponseWriter, if it supports flushing.
+func (w *StatusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Push implements http.Pusher by delegating to the embedded
+// ResponseWriter, if it supports server push.
+func (w *StatusWriter) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := w.ResponseWriter.(http.Pusher)
+
+	if !ok {
+		return http.ErrNotSupported
+	}
+
+	return pusher.Push(target, opts)
+}
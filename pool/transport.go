@@ -0,0 +1,12 @@
+package pool
+
+import "net/http"
+
+// Transport forwards a request to a single backend over whatever
+// protocol that backend speaks. ServeHTTP, retries, and circuit-breaker
+// accounting in main.go all go through this interface rather than a
+// concrete *httputil.ReverseProxy, so backends can be HTTP, FastCGI, or
+// anything else that can turn an *http.Request into a response.
+type Transport interface {
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+}